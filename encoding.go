@@ -0,0 +1,43 @@
+// Copyright 2019, Omnition
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinesis
+
+// encodingKind identifies the wire format a shardProducer marshals spans
+// into for Kinesis. Only marshalZipkin reads it, to choose between Zipkin
+// JSON and Zipkin proto; putZipkin hands each Zipkin span to the KPL
+// producer as its own record and does not batch it with other spans.
+type encodingKind int
+
+const (
+	encodingKindJaeger encodingKind = iota
+	encodingKindOC
+	encodingKindZipkinJSON
+	encodingKindZipkinProto
+)
+
+// encodingKindFor maps an Options.Encoding value to the encodingKind the
+// shard producers batch with.
+func encodingKindFor(encoding string) encodingKind {
+	switch encoding {
+	case encodingOC:
+		return encodingKindOC
+	case encodingZipkinProto:
+		return encodingKindZipkinProto
+	case encodingZipkinJSON:
+		return encodingKindZipkinJSON
+	default:
+		return encodingKindJaeger
+	}
+}
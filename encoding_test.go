@@ -0,0 +1,37 @@
+// Copyright 2019, Omnition
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinesis
+
+import "testing"
+
+func TestEncodingKindFor(t *testing.T) {
+	tests := []struct {
+		encoding string
+		want     encodingKind
+	}{
+		{encodingJaeger, encodingKindJaeger},
+		{"", encodingKindJaeger},
+		{"unknown", encodingKindJaeger},
+		{encodingOC, encodingKindOC},
+		{encodingZipkinJSON, encodingKindZipkinJSON},
+		{encodingZipkinProto, encodingKindZipkinProto},
+	}
+
+	for _, tt := range tests {
+		if got := encodingKindFor(tt.encoding); got != tt.want {
+			t.Errorf("encodingKindFor(%q) = %v, want %v", tt.encoding, got, tt.want)
+		}
+	}
+}
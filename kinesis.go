@@ -16,10 +16,12 @@
 package kinesis // import "github.com/omnition/opencensus-go-exporter-kinesis"
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -32,16 +34,20 @@ import (
 
 	gen "github.com/jaegertracing/jaeger/model"
 	producer "github.com/omnition/omnition-kinesis-producer"
+	zipkinmodel "github.com/openzipkin/zipkin-go/model"
 	"go.opencensus.io/stats/view"
+	"go.opencensus.io/trace"
 	"go.uber.org/zap"
 )
 
 const (
-	encodingJaeger = "jaeger-proto"
-	encodingOC     = "oc-proto"
+	encodingJaeger      = "jaeger-proto"
+	encodingOC          = "oc-proto"
+	encodingZipkinJSON  = "zipkin-json"
+	encodingZipkinProto = "zipkin-proto"
 )
 
-var supportedEncodings = [2]string{encodingJaeger, encodingOC}
+var supportedEncodings = [4]string{encodingJaeger, encodingOC, encodingZipkinJSON, encodingZipkinProto}
 
 // Options are the options to be used when initializing a Jaeger exporter.
 type Options struct {
@@ -65,9 +71,28 @@ type Options struct {
 	KPLMaxBackoffSeconds    int
 	MaxAllowedSizePerSpan   int
 
-	// Encoding defines the format in which spans should be exporter to kinesis
-	// only Jaeger is supported right now
+	// Encoding defines the format in which spans should be exported to kinesis.
+	// One of jaeger-proto, oc-proto, zipkin-json or zipkin-proto.
 	Encoding string
+
+	// SamplerRate is the fraction of traces, in [0.0, 1.0], kept by a
+	// deterministic head-based sampler applied before spans are enqueued.
+	// All spans of a given trace ID hash the same way, so either every span
+	// of a trace is kept or every span of that trace is dropped, regardless
+	// of which exporter instance processes it. A rate of 0 (the default)
+	// disables sampling and keeps every span.
+	SamplerRate float64
+
+	// ShardRefreshInterval controls how often the exporter re-lists the
+	// stream's shards to pick up resharding. Defaults to 5 minutes.
+	ShardRefreshInterval time.Duration
+
+	// ServiceName identifies, in the Jaeger/Zipkin sense, the service that
+	// spans passed to ExportSpan belong to. It is attached to every span
+	// produced by the trace.Exporter path (gen.Span.Process, Zipkin's
+	// LocalEndpoint, and an OC "service.name" attribute) so downstream
+	// backends can tell which service emitted them.
+	ServiceName string
 }
 
 func (o Options) isValidEncoding() bool {
@@ -91,6 +116,10 @@ func NewExporter(o Options, logger *zap.Logger) (*Exporter, error) {
 		o.ListFlushInterval = 5
 	}
 
+	if o.ShardRefreshInterval == 0 {
+		o.ShardRefreshInterval = 5 * time.Minute
+	}
+
 	if o.MaxAllowedSizePerSpan == 0 {
 		o.MaxAllowedSizePerSpan = 900000
 	}
@@ -134,30 +163,7 @@ func NewExporter(o Options, logger *zap.Logger) (*Exporter, error) {
 
 	producers := make([]*shardProducer, 0, len(shards))
 	for _, shard := range shards {
-		hooks := newKinesisHooks(o.Name, o.StreamName, shard.shardId)
-		pr := producer.New(&producer.Config{
-			StreamName:          o.StreamName,
-			AggregateBatchSize:  o.KPLAggregateBatchSize,
-			AggregateBatchCount: o.KPLAggregateBatchCount,
-			BatchSize:           o.KPLBatchSize,
-			BatchCount:          o.KPLBatchCount,
-			BacklogCount:        o.KPLBacklogCount,
-			MaxConnections:      o.KPLMaxConnections,
-			FlushInterval:       time.Second * time.Duration(o.KPLFlushIntervalSeconds),
-			MaxRetries:          o.KPLMaxRetries,
-			MaxBackoffTime:      time.Second * time.Duration(o.KPLMaxBackoffSeconds),
-			Client:              client,
-			Verbose:             false,
-		}, hooks)
-		producers = append(producers, &shardProducer{
-			pr:            pr,
-			shard:         shard,
-			hooks:         hooks,
-			maxSize:       uint64(o.MaxListSize),
-			flushInterval: time.Duration(o.ListFlushInterval) * time.Second,
-			partitionKey:  shard.startingHashKey.String(),
-			isJaeger:      o.Encoding == encodingJaeger,
-		})
+		producers = append(producers, newShardProducer(client, &o, shard))
 	}
 
 	e := &Exporter{
@@ -166,6 +172,7 @@ func NewExporter(o Options, logger *zap.Logger) (*Exporter, error) {
 		logger:    logger,
 		hooks:     newKinesisHooks(o.Name, o.StreamName, ""),
 		semaphore: nil,
+		client:    client,
 	}
 
 	maxReceivers, _ := strconv.Atoi(os.Getenv("MAX_KINESIS_RECEIVERS"))
@@ -174,6 +181,8 @@ func NewExporter(o Options, logger *zap.Logger) (*Exporter, error) {
 	}
 
 	v := metricViews()
+	v = append(v, samplerViews()...)
+	v = append(v, shardMetricViews()...)
 	if err := view.Register(v...); err != nil {
 		return nil, err
 	}
@@ -182,9 +191,42 @@ func NewExporter(o Options, logger *zap.Logger) (*Exporter, error) {
 		sp.start()
 	}
 
+	e.startShardRefresh()
+
 	return e, nil
 }
 
+// newShardProducer builds the KPL producer and shardProducer for a single
+// Kinesis shard, wiring it up with the options common to every shard in
+// the stream.
+func newShardProducer(client *kinesis.Kinesis, o *Options, shard *shard) *shardProducer {
+	hooks := newKinesisHooks(o.Name, o.StreamName, shard.shardId)
+	pr := producer.New(&producer.Config{
+		StreamName:          o.StreamName,
+		AggregateBatchSize:  o.KPLAggregateBatchSize,
+		AggregateBatchCount: o.KPLAggregateBatchCount,
+		BatchSize:           o.KPLBatchSize,
+		BatchCount:          o.KPLBatchCount,
+		BacklogCount:        o.KPLBacklogCount,
+		MaxConnections:      o.KPLMaxConnections,
+		FlushInterval:       time.Second * time.Duration(o.KPLFlushIntervalSeconds),
+		MaxRetries:          o.KPLMaxRetries,
+		MaxBackoffTime:      time.Second * time.Duration(o.KPLMaxBackoffSeconds),
+		Client:              client,
+		Verbose:             false,
+	}, hooks)
+	return &shardProducer{
+		pr:            pr,
+		shard:         shard,
+		hooks:         hooks,
+		maxSize:       uint64(o.MaxListSize),
+		flushInterval: time.Duration(o.ListFlushInterval) * time.Second,
+		partitionKey:  shard.startingHashKey.String(),
+		isJaeger:      o.Encoding == encodingJaeger,
+		encoding:      encodingKindFor(o.Encoding),
+	}
+}
+
 // Exporter takes spans in jaeger proto format and forwards them to a kinesis stream
 type Exporter struct {
 	options   *Options
@@ -192,20 +234,70 @@ type Exporter struct {
 	logger    *zap.Logger
 	hooks     *kinesisHooks
 	semaphore chan struct{}
+	client    *kinesis.Kinesis
+
+	// mu guards producers, which is rewritten whenever refreshShards adds or
+	// removes a shard producer after a reshard.
+	mu sync.RWMutex
+	// stopRefresh, when closed, stops the shard refresh goroutine.
+	stopRefresh chan struct{}
+
+	// wg tracks spans that have been handed to a process*Span goroutine but
+	// haven't been dequeued yet, so Flush can wait for them to drain.
+	wg sync.WaitGroup
+	// drained is closed once wg fully drains. drainOnce ensures only one
+	// goroutine ever waits on wg, so a Flush that times out and gets
+	// called again doesn't spawn another waiter.
+	drained   chan struct{}
+	drainOnce sync.Once
+	// shutdownOnce guards against a second Flush trying to stop producers
+	// and close the semaphore that the first Flush already closed.
+	shutdownOnce sync.Once
 }
 
-// Note: We do not implement trace.Exporter interface yet but it is planned
-// var _ trace.Exporter = (*Exporter)(nil)
-
-// Flush flushes queues and stops exporters
-func (e *Exporter) Flush() {
-	for _, sp := range e.producers {
-		sp.pr.Stop()
+var _ trace.Exporter = (*Exporter)(nil)
+
+// Flush waits, up to ctx's deadline, for spans already handed to the
+// exporter to finish processing, then stops every shard producer and the
+// shard refresh goroutine. It is safe to call more than once, including
+// after a prior call timed out: only the first call's waiter goroutine
+// runs, and only the first call that observes a full drain stops anything.
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.drainOnce.Do(func() {
+		e.drained = make(chan struct{})
+		go func() {
+			e.wg.Wait()
+			close(e.drained)
+		}()
+	})
+
+	select {
+	case <-e.drained:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	close(e.semaphore)
+
+	e.shutdownOnce.Do(func() {
+		if e.stopRefresh != nil {
+			close(e.stopRefresh)
+		}
+		e.mu.RLock()
+		producers := make([]*shardProducer, len(e.producers))
+		copy(producers, e.producers)
+		e.mu.RUnlock()
+		for _, sp := range producers {
+			sp.pr.Stop()
+		}
+		if e.semaphore != nil {
+			close(e.semaphore)
+		}
+	})
+
+	return nil
 }
 
 func (e *Exporter) acquire() {
+	e.wg.Add(1)
 	if e.semaphore != nil {
 		e.semaphore <- struct{}{}
 	}
@@ -217,13 +309,12 @@ func (e *Exporter) release() {
 	}
 }
 
-// ExportSpan exports a Jaeger protbuf span to Kinesis
-func (e *Exporter) ExportSpan(span *gen.Span) error {
-	return e.ExportJaegerSpan(span)
-}
-
 // ExportJaegerSpan exports an OC span to kinesis
 func (e *Exporter) ExportJaegerSpan(span *gen.Span) error {
+	if !e.sampledIn(hashTraceID(span.TraceID.String())) {
+		e.hooks.OnSpanSampledOut()
+		return nil
+	}
 	e.hooks.OnSpanEnqueued()
 	e.acquire()
 	go e.processJaegerSpan(span)
@@ -232,23 +323,41 @@ func (e *Exporter) ExportJaegerSpan(span *gen.Span) error {
 
 // ExportOCSpan exports an OC span to kinesis
 func (e *Exporter) ExportOCSpan(span *tracepb.Span) error {
+	if !e.sampledIn(hashTraceID(string(span.TraceId))) {
+		e.hooks.OnSpanSampledOut()
+		return nil
+	}
 	e.hooks.OnSpanEnqueued()
 	e.acquire()
 	go e.processOCSpan(span)
 	return nil
 }
 
+// ExportZipkinSpan exports a Zipkin span to kinesis
+func (e *Exporter) ExportZipkinSpan(span *zipkinmodel.SpanModel) error {
+	if !e.sampledIn(hashTraceID(span.TraceID.String())) {
+		e.hooks.OnSpanSampledOut()
+		return nil
+	}
+	e.hooks.OnSpanEnqueued()
+	e.acquire()
+	go e.processZipkinSpan(span)
+	return nil
+}
+
 func (e *Exporter) processJaegerSpan(span *gen.Span) {
+	defer e.wg.Done()
 	defer e.hooks.OnSpanDequeued()
-	sp, err := e.getShardProducer(span.TraceID.String())
+	traceID := span.TraceID.String()
+	sp, err := e.getShardProducer(traceID)
 	if err != nil {
-		fmt.Println("failed to get producer/shard for traceID: ", err)
+		e.logger.Error("failed to get producer/shard for traceID", zap.String("trace_id", traceID), zap.Error(err))
 		return
 	}
 	// todo: see if we can use span.Size() instead
 	encoded, err := gogoproto.Marshal(span)
 	if err != nil {
-		fmt.Println("failed to marshal: ", err)
+		e.logger.Error("failed to marshal jaeger span", zap.String("trace_id", traceID), zap.Error(err))
 		return
 	}
 	size := len(encoded)
@@ -262,7 +371,7 @@ func (e *Exporter) processJaegerSpan(span *gen.Span) {
 		span.Logs = []gen.Log{}
 		encoded, err = gogoproto.Marshal(span)
 		if err != nil {
-			fmt.Println("failed to modified span: ", err)
+			e.logger.Error("failed to marshal modified jaeger span", zap.String("trace_id", traceID), zap.Error(err))
 			return
 		}
 		size = len(encoded)
@@ -273,20 +382,27 @@ func (e *Exporter) processJaegerSpan(span *gen.Span) {
 	// err = sp.pr.Put(encoded, traceID)
 	err = sp.putJaeger(span, uint64(size))
 	if err != nil {
-		fmt.Println("error putting span: ", err)
+		e.logger.Error("failed to put jaeger span",
+			zap.String("trace_id", traceID),
+			zap.String("shard_id", sp.shard.shardId),
+			zap.Int("encoded_size", size),
+			zap.Error(err),
+		)
 	}
 }
 
 func (e *Exporter) processOCSpan(span *tracepb.Span) {
+	defer e.wg.Done()
 	defer e.hooks.OnSpanDequeued()
+	traceID := fmt.Sprintf("%x", span.TraceId)
 	sp, err := e.getShardProducer(string(span.TraceId))
 	if err != nil {
-		fmt.Println("failed to get producer/shard for traceID: ", err)
+		e.logger.Error("failed to get producer/shard for traceID", zap.String("trace_id", traceID), zap.Error(err))
 		return
 	}
 	encoded, err := proto.Marshal(span)
 	if err != nil {
-		fmt.Println("failed to marshal to OC: ", err)
+		e.logger.Error("failed to marshal OC span", zap.String("trace_id", traceID), zap.Error(err))
 		return
 	}
 	size := len(encoded)
@@ -299,7 +415,7 @@ func (e *Exporter) processOCSpan(span *tracepb.Span) {
 		}
 		encoded, err = proto.Marshal(span)
 		if err != nil {
-			fmt.Println("failed to encode modified OC span: ", err)
+			e.logger.Error("failed to marshal modified OC span", zap.String("trace_id", traceID), zap.Error(err))
 			return
 		}
 		size = len(encoded)
@@ -310,7 +426,12 @@ func (e *Exporter) processOCSpan(span *tracepb.Span) {
 	// err = sp.pr.Put(encoded, traceID)
 	err = sp.putOC(span, uint64(size))
 	if err != nil {
-		fmt.Println("error putting span: ", err)
+		e.logger.Error("failed to put OC span",
+			zap.String("trace_id", traceID),
+			zap.String("shard_id", sp.shard.shardId),
+			zap.Int("encoded_size", size),
+			zap.Error(err),
+		)
 	}
 }
 
@@ -327,6 +448,8 @@ func (e *Exporter) loop() {
 */
 
 func (e *Exporter) getShardProducer(partitionKey string) (*shardProducer, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	for _, sp := range e.producers {
 		ok, err := sp.shard.belongsToShard(partitionKey)
 		if err != nil {
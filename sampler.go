@@ -0,0 +1,68 @@
+// Copyright 2019, Omnition
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinesis
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+)
+
+const maxUint64 = float64(math.MaxUint64)
+
+var mSpansSampledOut = stats.Int64(
+	"kinesis_exporter_spans_sampled_out",
+	"Number of spans dropped by head-based sampling",
+	stats.UnitDimensionless,
+)
+
+func samplerViews() []*view.View {
+	return []*view.View{
+		{
+			Name:        "kinesis_exporter_spans_sampled_out",
+			Measure:     mSpansSampledOut,
+			Description: "Number of spans dropped by head-based sampling",
+			Aggregation: view.Count(),
+		},
+	}
+}
+
+// OnSpanSampledOut records that a span was dropped by head-based sampling
+// before it was ever enqueued to a shard producer.
+func (h *kinesisHooks) OnSpanSampledOut() {
+	stats.Record(context.Background(), mSpansSampledOut.M(1))
+}
+
+// sampledIn reports whether the trace this span belongs to should be kept
+// given the exporter's configured SamplerRate. SamplerRate of 0 means no
+// sampling: every span is kept.
+func (e *Exporter) sampledIn(hash uint64) bool {
+	rate := e.options.SamplerRate
+	if rate <= 0 {
+		return true
+	}
+	return float64(hash)/maxUint64 < rate
+}
+
+// hashTraceID deterministically hashes a trace ID so that every span
+// belonging to the same trace samples the same way.
+func hashTraceID(id string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return h.Sum64()
+}
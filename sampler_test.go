@@ -0,0 +1,46 @@
+// Copyright 2019, Omnition
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinesis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHashTraceID(t *testing.T) {
+	const id = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	if hashTraceID(id) != hashTraceID(id) {
+		t.Error("hashTraceID must be deterministic for the same trace ID")
+	}
+	if hashTraceID(id) == hashTraceID("00000000000000000000000000000000") {
+		t.Error("hashTraceID should differ across distinct trace IDs")
+	}
+}
+
+func TestSampledIn(t *testing.T) {
+	zero := &Exporter{options: &Options{SamplerRate: 0}}
+	if !zero.sampledIn(math.MaxUint64) {
+		t.Error("SamplerRate 0 must keep every span, including the highest hash")
+	}
+
+	half := &Exporter{options: &Options{SamplerRate: 0.5}}
+	if !half.sampledIn(0) {
+		t.Error("a hash below the rate boundary must be sampled in")
+	}
+	if half.sampledIn(math.MaxUint64) {
+		t.Error("a hash above the rate boundary must be sampled out")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2019, Omnition
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinesis
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+)
+
+var (
+	mShardsAdded = stats.Int64(
+		"kinesis_exporter_shards_added",
+		"Number of shard producers started after a reshard",
+		stats.UnitDimensionless,
+	)
+	mShardsRemoved = stats.Int64(
+		"kinesis_exporter_shards_removed",
+		"Number of shard producers stopped after a reshard",
+		stats.UnitDimensionless,
+	)
+)
+
+func shardMetricViews() []*view.View {
+	return []*view.View{
+		{
+			Name:        "kinesis_exporter_shards_added",
+			Measure:     mShardsAdded,
+			Description: "Number of shard producers started after a reshard",
+			Aggregation: view.Count(),
+		},
+		{
+			Name:        "kinesis_exporter_shards_removed",
+			Measure:     mShardsRemoved,
+			Description: "Number of shard producers stopped after a reshard",
+			Aggregation: view.Count(),
+		},
+	}
+}
+
+// OnShardAdded records that a new shard producer was started for shardID
+// after a reshard was detected.
+func (h *kinesisHooks) OnShardAdded(shardID string) {
+	stats.Record(context.Background(), mShardsAdded.M(1))
+}
+
+// OnShardRemoved records that a shard producer for shardID was drained and
+// stopped after its shard disappeared from the stream.
+func (h *kinesisHooks) OnShardRemoved(shardID string) {
+	stats.Record(context.Background(), mShardsRemoved.M(1))
+}
@@ -0,0 +1,136 @@
+// Copyright 2019, Omnition
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinesis
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// startShardRefresh starts the background goroutine that periodically
+// re-lists the stream's shards so the exporter picks up resharding without
+// a restart.
+func (e *Exporter) startShardRefresh() {
+	e.stopRefresh = make(chan struct{})
+	go e.refreshShardsLoop()
+}
+
+func (e *Exporter) refreshShardsLoop() {
+	ticker := time.NewTicker(e.options.ShardRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.refreshShards(); err != nil {
+				e.logger.Error("failed to refresh shards", zap.Error(err))
+			}
+		case <-e.stopRefresh:
+			return
+		}
+	}
+}
+
+// reconcileShardProducers compares the shard IDs the exporter currently has
+// producers for against the shard IDs Kinesis just listed and reports which
+// shard IDs are new (need a producer started) and which have disappeared,
+// e.g. merged or otherwise closed (need their producer stopped).
+func reconcileShardProducers(currentIDs map[string]bool, listedIDs []string) (added, removed []string) {
+	seen := make(map[string]bool, len(listedIDs))
+	for _, id := range listedIDs {
+		seen[id] = true
+		if !currentIDs[id] {
+			added = append(added, id)
+		}
+	}
+	for id := range currentIDs {
+		if !seen[id] {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
+
+// refreshShards re-lists shards for the stream and reconciles the
+// exporter's shard producers against them: a shard that wasn't there
+// before gets a new producer, a shard that is gone (merged or otherwise
+// closed) has its producer drained and stopped.
+func (e *Exporter) refreshShards() error {
+	shards, err := getShards(e.client, e.options.StreamName)
+	if err != nil {
+		return err
+	}
+
+	e.mu.RLock()
+	current := make(map[string]*shardProducer, len(e.producers))
+	currentIDs := make(map[string]bool, len(e.producers))
+	for _, sp := range e.producers {
+		current[sp.shard.shardId] = sp
+		currentIDs[sp.shard.shardId] = true
+	}
+	e.mu.RUnlock()
+
+	byID := make(map[string]*shard, len(shards))
+	listedIDs := make([]string, 0, len(shards))
+	for _, shard := range shards {
+		byID[shard.shardId] = shard
+		listedIDs = append(listedIDs, shard.shardId)
+	}
+
+	addedIDs, removedIDs := reconcileShardProducers(currentIDs, listedIDs)
+	if len(addedIDs) == 0 && len(removedIDs) == 0 {
+		return nil
+	}
+
+	added := make([]*shardProducer, 0, len(addedIDs))
+	for _, id := range addedIDs {
+		sp := newShardProducer(e.client, e.options, byID[id])
+		sp.start()
+		added = append(added, sp)
+		e.hooks.OnShardAdded(id)
+	}
+
+	removedSet := make(map[string]bool, len(removedIDs))
+	removed := make([]*shardProducer, 0, len(removedIDs))
+	for _, id := range removedIDs {
+		removedSet[id] = true
+		removed = append(removed, current[id])
+	}
+
+	e.mu.Lock()
+	kept := make([]*shardProducer, 0, len(e.producers)+len(added))
+	for _, sp := range e.producers {
+		if !removedSet[sp.shard.shardId] {
+			kept = append(kept, sp)
+		}
+	}
+	e.producers = append(kept, added...)
+	e.mu.Unlock()
+
+	for _, sp := range removed {
+		go e.drainAndStopShard(sp)
+	}
+
+	return nil
+}
+
+// drainAndStopShard gives a removed shard's producer one more flush
+// interval to ship spans that were already routed to it before stopping
+// it, so a reshard doesn't drop spans in flight.
+func (e *Exporter) drainAndStopShard(sp *shardProducer) {
+	time.Sleep(sp.flushInterval + time.Second)
+	sp.pr.Stop()
+	e.hooks.OnShardRemoved(sp.shard.shardId)
+}
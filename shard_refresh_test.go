@@ -0,0 +1,86 @@
+// Copyright 2019, Omnition
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinesis
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestReconcileShardProducers(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     map[string]bool
+		listed      []string
+		wantAdded   []string
+		wantRemoved []string
+	}{
+		{
+			name:    "no change",
+			current: map[string]bool{"shard-1": true, "shard-2": true},
+			listed:  []string{"shard-1", "shard-2"},
+		},
+		{
+			name:      "shard split adds a new shard",
+			current:   map[string]bool{"shard-1": true},
+			listed:    []string{"shard-1", "shard-2"},
+			wantAdded: []string{"shard-2"},
+		},
+		{
+			name:        "shard merged away",
+			current:     map[string]bool{"shard-1": true, "shard-2": true},
+			listed:      []string{"shard-1"},
+			wantRemoved: []string{"shard-2"},
+		},
+		{
+			name:        "shard replaced by a new one",
+			current:     map[string]bool{"shard-1": true},
+			listed:      []string{"shard-2"},
+			wantAdded:   []string{"shard-2"},
+			wantRemoved: []string{"shard-1"},
+		},
+		{
+			name:    "no current producers and no shards listed",
+			current: map[string]bool{},
+			listed:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := reconcileShardProducers(tt.current, tt.listed)
+			sort.Strings(added)
+			sort.Strings(removed)
+			if !equalStringSlices(added, tt.wantAdded) {
+				t.Errorf("added = %v, want %v", added, tt.wantAdded)
+			}
+			if !equalStringSlices(removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func equalStringSlices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
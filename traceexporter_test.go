@@ -0,0 +1,124 @@
+// Copyright 2019, Omnition
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinesis
+
+import (
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+func testSpanData() *trace.SpanData {
+	return &trace.SpanData{
+		SpanContext: trace.SpanContext{
+			TraceID: trace.TraceID{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2},
+			SpanID:  trace.SpanID{0, 0, 0, 0, 0, 0, 0, 3},
+		},
+		ParentSpanID: trace.SpanID{0, 0, 0, 0, 0, 0, 0, 4},
+		Name:         "test-span",
+		StartTime:    time.Unix(0, 0),
+		EndTime:      time.Unix(1, 0),
+		Attributes: map[string]interface{}{
+			"str":   "value",
+			"bool":  true,
+			"int":   int64(42),
+			"float": float64(3.5),
+		},
+	}
+}
+
+func TestJaegerSpanFromSpanData(t *testing.T) {
+	e := &Exporter{options: &Options{ServiceName: "svc"}}
+	span := e.jaegerSpanFromSpanData(testSpanData())
+
+	if span.OperationName != "test-span" {
+		t.Errorf("OperationName = %q, want test-span", span.OperationName)
+	}
+	if span.TraceID.High != 1 || span.TraceID.Low != 2 {
+		t.Errorf("TraceID = %+v, want {High:1 Low:2}", span.TraceID)
+	}
+	if span.SpanID != 3 {
+		t.Errorf("SpanID = %v, want 3", span.SpanID)
+	}
+	if span.Duration != time.Second {
+		t.Errorf("Duration = %v, want 1s", span.Duration)
+	}
+	if span.Process == nil || span.Process.ServiceName != "svc" {
+		t.Errorf("Process = %+v, want ServiceName svc", span.Process)
+	}
+	if len(span.References) != 1 || span.References[0].SpanID != 4 {
+		t.Errorf("References = %+v, want a single child-of ref to span 4", span.References)
+	}
+}
+
+func TestJaegerSpanFromSpanDataNoServiceName(t *testing.T) {
+	e := &Exporter{options: &Options{}}
+	span := e.jaegerSpanFromSpanData(testSpanData())
+	if span.Process != nil {
+		t.Errorf("Process = %+v, want nil when ServiceName is unset", span.Process)
+	}
+}
+
+func TestOCSpanFromSpanData(t *testing.T) {
+	e := &Exporter{options: &Options{ServiceName: "svc"}}
+	span := e.ocSpanFromSpanData(testSpanData())
+
+	if span.Name.Value != "test-span" {
+		t.Errorf("Name = %q, want test-span", span.Name.Value)
+	}
+	serviceName, ok := span.Attributes.AttributeMap["service.name"]
+	if !ok {
+		t.Fatal("expected a service.name attribute")
+	}
+	if got := serviceName.GetStringValue().GetValue(); got != "svc" {
+		t.Errorf("service.name = %q, want svc", got)
+	}
+	floatAttr, ok := span.Attributes.AttributeMap["float"]
+	if !ok || floatAttr.GetDoubleValue() != 3.5 {
+		t.Errorf("float attribute = %+v, want DoubleValue 3.5", floatAttr)
+	}
+}
+
+func TestOCSpanFromSpanDataNoServiceName(t *testing.T) {
+	e := &Exporter{options: &Options{}}
+	span := e.ocSpanFromSpanData(testSpanData())
+	if _, ok := span.Attributes.AttributeMap["service.name"]; ok {
+		t.Error("expected no service.name attribute when ServiceName is unset")
+	}
+}
+
+func TestZipkinSpanFromSpanData(t *testing.T) {
+	e := &Exporter{options: &Options{ServiceName: "svc"}}
+	span := e.zipkinSpanFromSpanData(testSpanData())
+
+	if span.Name != "test-span" {
+		t.Errorf("Name = %q, want test-span", span.Name)
+	}
+	if span.LocalEndpoint == nil || span.LocalEndpoint.ServiceName != "svc" {
+		t.Errorf("LocalEndpoint = %+v, want ServiceName svc", span.LocalEndpoint)
+	}
+	if span.ParentID == nil || *span.ParentID != 4 {
+		t.Errorf("ParentID = %v, want 4", span.ParentID)
+	}
+}
+
+func TestZipkinSpanFromSpanDataNoServiceName(t *testing.T) {
+	e := &Exporter{options: &Options{}}
+	span := e.zipkinSpanFromSpanData(testSpanData())
+	if span.LocalEndpoint != nil {
+		t.Errorf("LocalEndpoint = %+v, want nil when ServiceName is unset", span.LocalEndpoint)
+	}
+}
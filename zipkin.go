@@ -0,0 +1,86 @@
+// Copyright 2019, Omnition
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinesis
+
+import (
+	"encoding/json"
+	"fmt"
+
+	zipkinmodel "github.com/openzipkin/zipkin-go/model"
+	zipkinproto "github.com/openzipkin/zipkin-go/proto/zipkin_proto3"
+	"go.uber.org/zap"
+)
+
+var zipkinProtoSerializer = zipkinproto.SpanSerializer{}
+
+func (e *Exporter) processZipkinSpan(span *zipkinmodel.SpanModel) {
+	defer e.wg.Done()
+	defer e.hooks.OnSpanDequeued()
+	traceID := span.TraceID.String()
+	sp, err := e.getShardProducer(traceID)
+	if err != nil {
+		e.logger.Error("failed to get producer/shard for traceID", zap.String("trace_id", traceID), zap.Error(err))
+		return
+	}
+	encoded, err := sp.marshalZipkin(span)
+	if err != nil {
+		e.logger.Error("failed to marshal zipkin span", zap.String("trace_id", traceID), zap.Error(err))
+		return
+	}
+	size := len(encoded)
+	if size > e.options.MaxAllowedSizePerSpan {
+		sp.hooks.OnXLSpanDropped(size)
+		span.Tags = map[string]string{
+			"omnition.dropped":        "true",
+			"omnition.dropped.reason": "unsupported size",
+			"omnition.dropped.size":   fmt.Sprintf("%d", size),
+		}
+		span.Annotations = nil
+		encoded, err = sp.marshalZipkin(span)
+		if err != nil {
+			e.logger.Error("failed to marshal modified zipkin span", zap.String("trace_id", traceID), zap.Error(err))
+			return
+		}
+		size = len(encoded)
+	}
+	err = sp.putZipkin(span, uint64(size))
+	if err != nil {
+		e.logger.Error("failed to put zipkin span",
+			zap.String("trace_id", traceID),
+			zap.String("shard_id", sp.shard.shardId),
+			zap.Int("encoded_size", size),
+			zap.Error(err),
+		)
+	}
+}
+
+// marshalZipkin encodes span using whichever of zipkin-json/zipkin-proto
+// this shard's stream is configured for.
+func (sp *shardProducer) marshalZipkin(span *zipkinmodel.SpanModel) ([]byte, error) {
+	if sp.encoding == encodingKindZipkinProto {
+		return zipkinProtoSerializer.Serialize([]*zipkinmodel.SpanModel{span})
+	}
+	return json.Marshal(span)
+}
+
+// putZipkin marshals span to the encoding configured for the stream
+// (zipkin-json or zipkin-proto) and hands it to the underlying KPL producer.
+func (sp *shardProducer) putZipkin(span *zipkinmodel.SpanModel, size uint64) error {
+	encoded, err := sp.marshalZipkin(span)
+	if err != nil {
+		return err
+	}
+	return sp.pr.Put(encoded, sp.partitionKey)
+}
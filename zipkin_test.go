@@ -0,0 +1,57 @@
+// Copyright 2019, Omnition
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinesis
+
+import (
+	"bytes"
+	"testing"
+
+	zipkinmodel "github.com/openzipkin/zipkin-go/model"
+)
+
+func testZipkinSpan() *zipkinmodel.SpanModel {
+	return &zipkinmodel.SpanModel{
+		SpanContext: zipkinmodel.SpanContext{
+			TraceID: zipkinmodel.TraceID{High: 1, Low: 2},
+			ID:      zipkinmodel.ID(3),
+		},
+		Name: "test-span",
+	}
+}
+
+func TestMarshalZipkinJSON(t *testing.T) {
+	sp := &shardProducer{encoding: encodingKindZipkinJSON}
+	encoded, err := sp.marshalZipkin(testZipkinSpan())
+	if err != nil {
+		t.Fatalf("marshalZipkin: %v", err)
+	}
+	if len(encoded) == 0 || encoded[0] != '{' {
+		t.Errorf("expected a JSON object for zipkin-json, got %q", encoded)
+	}
+}
+
+func TestMarshalZipkinProto(t *testing.T) {
+	sp := &shardProducer{encoding: encodingKindZipkinProto}
+	encoded, err := sp.marshalZipkin(testZipkinSpan())
+	if err != nil {
+		t.Fatalf("marshalZipkin: %v", err)
+	}
+	if len(encoded) == 0 {
+		t.Fatal("expected non-empty protobuf-encoded span for zipkin-proto")
+	}
+	if bytes.HasPrefix(encoded, []byte("{")) {
+		t.Errorf("expected protobuf bytes for zipkin-proto, got JSON-looking output: %q", encoded)
+	}
+}